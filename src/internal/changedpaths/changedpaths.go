@@ -0,0 +1,138 @@
+// Package changedpaths resolves which files changed between a base commit
+// and HEAD and filters them against the action's "paths" input, so callers
+// can decide whether a job is actually worth running.
+package changedpaths
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/google/go-github/v54/github"
+)
+
+const githubSHAEnv = "GITHUB_SHA"
+
+// Result is everything a caller needs to set the changed/changed-files/
+// any-matched outputs.
+type Result struct {
+	// Files is every path that changed between base and HEAD, regardless
+	// of whether it matched a pattern.
+	Files []string
+
+	// Matched is the subset of Files that matched the "paths" patterns.
+	Matched []string
+}
+
+// AnyMatched reports whether at least one changed file matched a pattern.
+func (r Result) AnyMatched() bool {
+	return len(r.Matched) > 0
+}
+
+// Resolve returns the files changed between baseSHA and HEAD, filtered
+// against patterns. It first tries a local `git diff`, and falls back to
+// the GitHub Compare API when the checkout is too shallow to contain
+// baseSHA (the common case when actions/checkout isn't given
+// fetch-depth: 0).
+func Resolve(ctx context.Context, client *github.Client, owner, repo, baseSHA, headSHA string, patterns []string) (Result, error) {
+	files, err := diffNames(baseSHA, headSHA)
+	if err != nil {
+		// Most likely a shallow checkout that doesn't have baseSHA locally.
+		files, err = compareNames(ctx, client, owner, repo, baseSHA, headSHA)
+		if err != nil {
+			return Result{}, fmt.Errorf("resolving changed files: %w", err)
+		}
+	}
+
+	return Result{
+		Files:   files,
+		Matched: filter(files, patterns),
+	}, nil
+}
+
+// CurrentHeadSHA returns the commit being evaluated: GITHUB_SHA when the
+// action runner sets it, falling back to `git rev-parse HEAD` for local
+// invocations. The Compare API has no ref literally named "HEAD", so
+// callers must resolve this before calling Resolve.
+func CurrentHeadSHA() (string, error) {
+	if sha := os.Getenv(githubSHAEnv); sha != "" {
+		return sha, nil
+	}
+
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+func diffNames(baseSHA, headSHA string) ([]string, error) {
+	cmd := exec.Command("git", "diff", "--name-only", fmt.Sprintf("%s...%s", baseSHA, headSHA))
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return splitLines(stdout.String()), nil
+}
+
+func compareNames(ctx context.Context, client *github.Client, owner, repo, baseSHA, headSHA string) ([]string, error) {
+	comparison, _, err := client.Repositories.CompareCommits(ctx, owner, repo, baseSHA, headSHA, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]string, 0, len(comparison.Files))
+	for _, f := range comparison.Files {
+		files = append(files, f.GetFilename())
+	}
+	return files, nil
+}
+
+func splitLines(s string) []string {
+	var out []string
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+// filter keeps the files that match patterns, applying them in order so
+// that later negated patterns (prefixed with "!") can exclude matches from
+// earlier ones, gitignore-style.
+func filter(files []string, patterns []string) []string {
+	if len(patterns) == 0 {
+		return nil
+	}
+
+	var matched []string
+	for _, file := range files {
+		include := false
+		for _, pattern := range patterns {
+			negate := strings.HasPrefix(pattern, "!")
+			clean := strings.TrimPrefix(pattern, "!")
+
+			ok, err := doublestar.Match(clean, file)
+			if err != nil || !ok {
+				continue
+			}
+
+			include = !negate
+		}
+		if include {
+			matched = append(matched, file)
+		}
+	}
+	return matched
+}
@@ -0,0 +1,49 @@
+package changedpaths
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    []string
+		patterns []string
+		want     []string
+	}{
+		{
+			name:     "no patterns matches nothing",
+			files:    []string{"src/main.go"},
+			patterns: nil,
+			want:     nil,
+		},
+		{
+			name:     "doublestar matches nested paths",
+			files:    []string{"src/internal/ghclient/ghclient.go", "README.md"},
+			patterns: []string{"src/**"},
+			want:     []string{"src/internal/ghclient/ghclient.go"},
+		},
+		{
+			name:     "negated pattern excludes a previous match",
+			files:    []string{"src/main.go", "src/main_test.go"},
+			patterns: []string{"src/**", "!src/**_test.go"},
+			want:     []string{"src/main.go"},
+		},
+		{
+			name:     "later pattern can re-include a negated match",
+			files:    []string{"src/main_test.go"},
+			patterns: []string{"src/**", "!src/**_test.go", "src/main_test.go"},
+			want:     []string{"src/main_test.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filter(tt.files, tt.patterns)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filter(%v, %v) = %v, want %v", tt.files, tt.patterns, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+// Package ghclient builds an authenticated *github.Client for the action,
+// preferring (in order) an explicit token input, the runner-provided
+// GITHUB_TOKEN, or a GitHub App installation token.
+package ghclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/bradleyfalzon/ghinstallation/v2"
+	"github.com/google/go-github/v54/github"
+	"golang.org/x/oauth2"
+)
+
+const githubTokenEnv = "GITHUB_TOKEN"
+
+// Options configures how the client is authenticated and, for GitHub
+// Enterprise Server users, which API base URL it talks to.
+type Options struct {
+	// Token is an explicit personal access token or GITHUB_TOKEN value.
+	// When empty, the GITHUB_TOKEN environment variable is used instead.
+	Token string
+
+	// AppID, InstallationID and PrivateKey authenticate as a GitHub App
+	// installation. All three must be set to take effect, and are only used
+	// when Token (and GITHUB_TOKEN) are both unset.
+	AppID          int64
+	InstallationID int64
+	PrivateKey     string
+
+	// APIURL, when set, points the client at a GitHub Enterprise Server
+	// instance instead of github.com.
+	APIURL string
+}
+
+// New builds a *github.Client per Options, falling back to an
+// unauthenticated client if neither a token nor App credentials are
+// supplied.
+func New(ctx context.Context, opts Options) (*github.Client, error) {
+	httpClient, err := transport(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.APIURL == "" {
+		return github.NewClient(httpClient), nil
+	}
+
+	return github.NewEnterpriseClient(opts.APIURL, opts.APIURL, httpClient)
+}
+
+func transport(opts Options) (*http.Client, error) {
+	token := opts.Token
+	if token == "" {
+		token = os.Getenv(githubTokenEnv)
+	}
+	if token != "" {
+		ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
+		return oauth2.NewClient(context.Background(), ts), nil
+	}
+
+	if opts.AppID != 0 && opts.InstallationID != 0 && opts.PrivateKey != "" {
+		tr, err := ghinstallation.New(http.DefaultTransport, opts.AppID, opts.InstallationID, []byte(opts.PrivateKey))
+		if err != nil {
+			return nil, fmt.Errorf("building GitHub App transport: %w", err)
+		}
+		if opts.APIURL != "" {
+			tr.BaseURL = opts.APIURL
+		}
+		return &http.Client{Transport: tr}, nil
+	}
+
+	return nil, nil
+}
+
+// ParseInt64 converts a raw action input into an int64, returning 0 for an
+// empty string so optional numeric inputs like app-id/installation-id can
+// be left unset.
+func ParseInt64(raw string) (int64, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(raw, 10, 64)
+}
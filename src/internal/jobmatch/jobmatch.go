@@ -0,0 +1,65 @@
+// Package jobmatch matches workflow job names against the "job" input,
+// which may be a plain name, a comma-separated list, or a glob pattern
+// like "build (*)" for matrix-expanded jobs.
+package jobmatch
+
+import (
+	"path"
+	"strings"
+)
+
+// ParseSpecs splits the "job" input into individual specs on commas,
+// trimming whitespace around each one. Commas inside parentheses are kept
+// intact so an exact matrix leg name like "build (ubuntu-latest, 1.21)"
+// survives as a single spec instead of being split apart.
+func ParseSpecs(raw string) []string {
+	var specs []string
+	depth := 0
+	start := 0
+
+	flush := func(end int) {
+		if trimmed := strings.TrimSpace(raw[start:end]); trimmed != "" {
+			specs = append(specs, trimmed)
+		}
+		start = end + 1
+	}
+
+	for i, r := range raw {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			if depth > 0 {
+				depth--
+			}
+		case ',':
+			if depth == 0 {
+				flush(i)
+			}
+		}
+	}
+	flush(len(raw))
+
+	return specs
+}
+
+// Matches reports whether jobName satisfies spec, treating spec as a glob
+// pattern when it contains any wildcard characters and as an exact name
+// otherwise.
+func Matches(spec, jobName string) bool {
+	if !strings.ContainsAny(spec, "*?[") {
+		return spec == jobName
+	}
+	ok, err := path.Match(spec, jobName)
+	return err == nil && ok
+}
+
+// BaseName strips a matrix leg's parenthesized suffix, so
+// "build (ubuntu-latest, 1.21)" becomes "build". Names without a matrix
+// suffix are returned unchanged.
+func BaseName(jobName string) string {
+	if i := strings.Index(jobName, " ("); i != -1 {
+		return jobName[:i]
+	}
+	return jobName
+}
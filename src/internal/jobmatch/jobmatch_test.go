@@ -0,0 +1,79 @@
+package jobmatch
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSpecs(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want []string
+	}{
+		{
+			name: "single job name",
+			raw:  "build",
+			want: []string{"build"},
+		},
+		{
+			name: "comma-separated job names",
+			raw:  "build, test",
+			want: []string{"build", "test"},
+		},
+		{
+			name: "matrix leg name keeps its internal commas intact",
+			raw:  "build (ubuntu-latest, 1.21)",
+			want: []string{"build (ubuntu-latest, 1.21)"},
+		},
+		{
+			name: "mix of a plain name and a matrix leg name",
+			raw:  "lint, build (ubuntu-latest, 1.21), build (macos-latest, 1.21)",
+			want: []string{"lint", "build (ubuntu-latest, 1.21)", "build (macos-latest, 1.21)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseSpecs(tt.raw)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSpecs(%q) = %v, want %v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		spec    string
+		jobName string
+		want    bool
+	}{
+		{"build", "build", true},
+		{"build", "build (ubuntu-latest, 1.21)", false},
+		{"build (*)", "build (ubuntu-latest, 1.21)", true},
+		{"build (*)", "test (ubuntu-latest, 1.21)", false},
+	}
+
+	for _, tt := range tests {
+		if got := Matches(tt.spec, tt.jobName); got != tt.want {
+			t.Errorf("Matches(%q, %q) = %v, want %v", tt.spec, tt.jobName, got, tt.want)
+		}
+	}
+}
+
+func TestBaseName(t *testing.T) {
+	tests := []struct {
+		jobName string
+		want    string
+	}{
+		{"build", "build"},
+		{"build (ubuntu-latest, 1.21)", "build"},
+	}
+
+	for _, tt := range tests {
+		if got := BaseName(tt.jobName); got != tt.want {
+			t.Errorf("BaseName(%q) = %q, want %q", tt.jobName, got, tt.want)
+		}
+	}
+}
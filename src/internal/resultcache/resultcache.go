@@ -0,0 +1,262 @@
+// Package resultcache remembers the last workflow run resolved for a given
+// (repo, branch, job) so subsequent invocations can resume pagination from
+// run_id+1 instead of re-scanning the whole workflow history. It prefers
+// the GitHub Actions cache service and falls back to a file under
+// RUNNER_TEMP when that service isn't available (e.g. running locally).
+package resultcache
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	actionsCacheURLEnv     = "ACTIONS_CACHE_URL"
+	actionsRuntimeTokenEnv = "ACTIONS_RUNTIME_TOKEN"
+	runnerTempEnv          = "RUNNER_TEMP"
+
+	cacheVersion = "lsjr-v1"
+)
+
+// Entry is the cached result for one (repo, branch, job) key.
+type Entry struct {
+	RunID   int64  `json:"run_id"`
+	HeadSHA string `json:"head_sha"`
+
+	// RunCreatedAt is the cached workflow run's own CreatedAt, used to pick
+	// the oldest SHA when combining results across multiple job specs.
+	RunCreatedAt time.Time `json:"run_created_at"`
+
+	// ResolvedAt is when this entry was written, used only to apply the TTL.
+	ResolvedAt time.Time `json:"resolved_at"`
+}
+
+// Cache resolves and stores Entry values for a single key, honoring a TTL
+// beyond which a stored entry is treated as a miss.
+type Cache struct {
+	enabled bool
+	ttl     time.Duration
+	key     string
+}
+
+// New builds a Cache for (repo, branch, job). enabled mirrors the action's
+// "cache" input; ttl is parsed from "cache-ttl" and a zero value means
+// entries never expire on their own.
+func New(enabled bool, ttl time.Duration, repo, branch, job string) *Cache {
+	sum := sha256.Sum256([]byte(strings.Join([]string{repo, branch, job}, "\x00")))
+	return &Cache{
+		enabled: enabled,
+		ttl:     ttl,
+		key:     fmt.Sprintf("%s-%x", cacheVersion, sum),
+	}
+}
+
+// Get returns the cached entry, if any, that hasn't expired and whose run
+// still exists per runExists.
+func (c *Cache) Get(runExists func(runID int64) bool) (Entry, bool) {
+	if !c.enabled {
+		return Entry{}, false
+	}
+
+	entry, ok := c.restore()
+	if !ok {
+		return Entry{}, false
+	}
+
+	if c.ttl > 0 && time.Since(entry.ResolvedAt) > c.ttl {
+		return Entry{}, false
+	}
+
+	if !runExists(entry.RunID) {
+		return Entry{}, false
+	}
+
+	return entry, true
+}
+
+// Set stores entry, replacing whatever was previously cached for this key.
+func (c *Cache) Set(entry Entry) {
+	if !c.enabled {
+		return
+	}
+	entry.ResolvedAt = time.Now()
+	if err := c.save(entry); err != nil {
+		return
+	}
+}
+
+func (c *Cache) restore() (Entry, bool) {
+	if raw, ok := c.actionsCacheRestore(); ok {
+		var entry Entry
+		if err := json.Unmarshal(raw, &entry); err == nil {
+			return entry, true
+		}
+	}
+	return c.fileRestore()
+}
+
+func (c *Cache) save(entry Entry) error {
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	if c.actionsCacheSave(raw) {
+		return nil
+	}
+
+	return c.fileSave(raw)
+}
+
+func (c *Cache) cachePath() string {
+	dir := os.Getenv(runnerTempEnv)
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, fmt.Sprintf("%s.json", c.key))
+}
+
+func (c *Cache) fileRestore() (Entry, bool) {
+	raw, err := os.ReadFile(c.cachePath())
+	if err != nil {
+		return Entry{}, false
+	}
+	var entry Entry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return Entry{}, false
+	}
+	return entry, true
+}
+
+func (c *Cache) fileSave(raw []byte) error {
+	return os.WriteFile(c.cachePath(), raw, 0644)
+}
+
+// actionsCacheRestore fetches the cached blob from the Actions cache service REST API (v1/cache),
+// returning ok=false if the service isn't configured or the key is a cache miss.
+func (c *Cache) actionsCacheRestore() ([]byte, bool) {
+	baseURL, token := os.Getenv(actionsCacheURLEnv), os.Getenv(actionsRuntimeTokenEnv)
+	if baseURL == "" || token == "" {
+		return nil, false
+	}
+
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%sv1/cache?keys=%s&version=%s", baseURL, c.key, cacheVersion), nil)
+	if err != nil {
+		return nil, false
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json;api-version=6.0-preview.1")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, false
+	}
+
+	var meta struct {
+		ArchiveLocation string `json:"archiveLocation"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&meta); err != nil || meta.ArchiveLocation == "" {
+		return nil, false
+	}
+
+	archiveResp, err := http.Get(meta.ArchiveLocation)
+	if err != nil {
+		return nil, false
+	}
+	defer archiveResp.Body.Close()
+
+	raw, err := io.ReadAll(archiveResp.Body)
+	if err != nil {
+		return nil, false
+	}
+	return raw, true
+}
+
+// actionsCacheSave reserves, uploads and commits raw as the cache entry for this key,
+// following the three-step Actions cache service protocol. It reports whether the full
+// sequence succeeded so callers can fall back to the file cache otherwise.
+func (c *Cache) actionsCacheSave(raw []byte) bool {
+	baseURL, token := os.Getenv(actionsCacheURLEnv), os.Getenv(actionsRuntimeTokenEnv)
+	if baseURL == "" || token == "" {
+		return false
+	}
+
+	client := &http.Client{}
+	authHeader := func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Accept", "application/json;api-version=6.0-preview.1")
+	}
+
+	reserveBody, err := json.Marshal(map[string]any{"key": c.key, "version": cacheVersion})
+	if err != nil {
+		return false
+	}
+	reserveReq, err := http.NewRequest(http.MethodPost, baseURL+"v1/caches", strings.NewReader(string(reserveBody)))
+	if err != nil {
+		return false
+	}
+	authHeader(reserveReq)
+	reserveReq.Header.Set("Content-Type", "application/json")
+
+	reserveResp, err := client.Do(reserveReq)
+	if err != nil {
+		return false
+	}
+	defer reserveResp.Body.Close()
+	if reserveResp.StatusCode != http.StatusCreated && reserveResp.StatusCode != http.StatusOK {
+		return false
+	}
+
+	var reserved struct {
+		CacheID int64 `json:"cacheId"`
+	}
+	if err := json.NewDecoder(reserveResp.Body).Decode(&reserved); err != nil {
+		return false
+	}
+
+	uploadReq, err := http.NewRequest(http.MethodPatch, fmt.Sprintf("%sv1/caches/%d", baseURL, reserved.CacheID), strings.NewReader(string(raw)))
+	if err != nil {
+		return false
+	}
+	authHeader(uploadReq)
+	uploadReq.Header.Set("Content-Type", "application/octet-stream")
+	uploadReq.Header.Set("Content-Range", fmt.Sprintf("bytes 0-%d/*", len(raw)-1))
+
+	uploadResp, err := client.Do(uploadReq)
+	if err != nil {
+		return false
+	}
+	uploadResp.Body.Close()
+	if uploadResp.StatusCode != http.StatusNoContent {
+		return false
+	}
+
+	commitBody, err := json.Marshal(map[string]any{"size": len(raw)})
+	if err != nil {
+		return false
+	}
+	commitReq, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%sv1/caches/%d", baseURL, reserved.CacheID), strings.NewReader(string(commitBody)))
+	if err != nil {
+		return false
+	}
+	authHeader(commitReq)
+	commitReq.Header.Set("Content-Type", "application/json")
+
+	commitResp, err := client.Do(commitReq)
+	if err != nil {
+		return false
+	}
+	defer commitResp.Body.Close()
+	return commitResp.StatusCode == http.StatusNoContent || commitResp.StatusCode == http.StatusOK
+}
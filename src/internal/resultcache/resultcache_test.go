@@ -0,0 +1,77 @@
+package resultcache
+
+import (
+	"testing"
+	"time"
+)
+
+func alwaysExists(int64) bool { return true }
+func neverExists(int64) bool  { return false }
+
+func TestFileBackedRoundTrip(t *testing.T) {
+	t.Setenv("RUNNER_TEMP", t.TempDir())
+	t.Setenv("ACTIONS_CACHE_URL", "")
+	t.Setenv("ACTIONS_RUNTIME_TOKEN", "")
+
+	c := New(true, 0, "owner/repo", "main", "build")
+
+	if _, ok := c.Get(alwaysExists); ok {
+		t.Fatalf("expected a miss before anything is cached")
+	}
+
+	runCreatedAt := time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+	c.Set(Entry{RunID: 42, HeadSHA: "deadbeef", RunCreatedAt: runCreatedAt})
+
+	entry, ok := c.Get(alwaysExists)
+	if !ok {
+		t.Fatalf("expected a hit after Set")
+	}
+	if entry.RunID != 42 || entry.HeadSHA != "deadbeef" {
+		t.Fatalf("got %+v, want run 42 / sha deadbeef", entry)
+	}
+	if !entry.RunCreatedAt.Equal(runCreatedAt) {
+		t.Fatalf("RunCreatedAt = %v, want %v (got clobbered by the cache-write timestamp)", entry.RunCreatedAt, runCreatedAt)
+	}
+}
+
+func TestGetDisabled(t *testing.T) {
+	t.Setenv("RUNNER_TEMP", t.TempDir())
+
+	c := New(false, 0, "owner/repo", "main", "build")
+	c.Set(Entry{RunID: 1, HeadSHA: "abc"})
+
+	if _, ok := c.Get(alwaysExists); ok {
+		t.Fatalf("expected a disabled cache to never hit")
+	}
+}
+
+func TestGetInvalidatesWhenRunIsGone(t *testing.T) {
+	t.Setenv("RUNNER_TEMP", t.TempDir())
+
+	c := New(true, 0, "owner/repo", "main", "build")
+	c.Set(Entry{RunID: 1, HeadSHA: "abc"})
+
+	if _, ok := c.Get(neverExists); ok {
+		t.Fatalf("expected a miss once the cached run no longer exists")
+	}
+}
+
+func TestGetInvalidatesOnTTL(t *testing.T) {
+	t.Setenv("RUNNER_TEMP", t.TempDir())
+
+	c := New(true, time.Minute, "owner/repo", "main", "build")
+	c.Set(Entry{RunID: 1, HeadSHA: "abc"})
+
+	entry, ok := c.Get(alwaysExists)
+	if !ok {
+		t.Fatalf("expected a fresh entry to be within TTL")
+	}
+	entry.ResolvedAt = time.Now().Add(-time.Hour)
+	if err := c.save(entry); err != nil {
+		t.Fatalf("save: %s", err)
+	}
+
+	if _, ok := c.Get(alwaysExists); ok {
+		t.Fatalf("expected an expired entry to be a miss")
+	}
+}
@@ -0,0 +1,112 @@
+// Package scaffold generates a ready-to-use GitHub Actions workflow that
+// wires this action into a repository, for the "scaffold" subcommand.
+package scaffold
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const defaultWorkflowName = "latest-successful"
+
+// Options configures the generated workflow.
+type Options struct {
+	Job          string
+	Paths        string
+	WorkflowName string
+
+	// Force skips the overwrite confirmation prompt when the target file already exists.
+	Force bool
+}
+
+const workflowBody = `name: %s
+
+on:
+  push:
+    branches: [main]
+  pull_request:
+
+jobs:
+  lsjr:
+    runs-on: ubuntu-latest
+    outputs:
+      any-matched: ${{ steps.lsjr.outputs.any-matched }}
+    steps:
+      - uses: actions/checkout@v4
+        with:
+          fetch-depth: 0
+
+      - id: lsjr
+        uses: palsp/latest-successful-job-run@v1
+        with:
+          job: %s
+          paths: %s
+
+  build:
+    needs: lsjr
+    if: needs.lsjr.outputs.any-matched == 'true'
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - run: echo "paths changed since the last successful run, building"
+`
+
+// Run writes the scaffolded workflow file under dir (typically
+// ".github/workflows"), prompting for confirmation via in/out if a file of
+// the same name already exists and opts.Force is false.
+func Run(dir string, opts Options, in io.Reader, out io.Writer) error {
+	if opts.WorkflowName == "" {
+		opts.WorkflowName = defaultWorkflowName
+	}
+	if opts.Job == "" {
+		opts.Job = "build"
+	}
+	if opts.Paths == "" {
+		opts.Paths = "**"
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("%s.yaml", opts.WorkflowName))
+
+	if _, err := os.Stat(path); err == nil && !opts.Force {
+		if !confirmOverwrite(path, in, out) {
+			fmt.Fprintln(out, "Aborted, no file was written.")
+			return nil
+		}
+	} else if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("checking for existing workflow: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("creating %s: %w", dir, err)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if _, err := fmt.Fprintf(f, workflowBody, opts.WorkflowName, opts.Job, opts.Paths); err != nil {
+		return fmt.Errorf("writing workflow template: %w", err)
+	}
+
+	fmt.Fprintf(out, "Wrote %s\n", path)
+	return nil
+}
+
+func confirmOverwrite(path string, in io.Reader, out io.Writer) bool {
+	fmt.Fprintf(out, "%s already exists. Overwrite? [y/N] ", path)
+	scanner := bufio.NewScanner(in)
+	if !scanner.Scan() {
+		return false
+	}
+	switch scanner.Text() {
+	case "y", "Y", "yes":
+		return true
+	default:
+		return false
+	}
+}
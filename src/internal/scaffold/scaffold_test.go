@@ -0,0 +1,119 @@
+package scaffold
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunWritesDefaults(t *testing.T) {
+	dir := t.TempDir()
+	var out bytes.Buffer
+
+	if err := Run(dir, Options{}, strings.NewReader(""), &out); err != nil {
+		t.Fatalf("Run: %s", err)
+	}
+
+	path := filepath.Join(dir, defaultWorkflowName+".yaml")
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading %s: %s", path, err)
+	}
+
+	if !strings.Contains(string(raw), "job: build") {
+		t.Errorf("expected the default job name, got:\n%s", raw)
+	}
+	if !strings.Contains(string(raw), "paths: **") {
+		t.Errorf("expected the default paths pattern, got:\n%s", raw)
+	}
+}
+
+func TestRunCustomOptions(t *testing.T) {
+	dir := t.TempDir()
+	var out bytes.Buffer
+
+	opts := Options{Job: "test", Paths: "src/**", WorkflowName: "custom"}
+	if err := Run(dir, opts, strings.NewReader(""), &out); err != nil {
+		t.Fatalf("Run: %s", err)
+	}
+
+	raw, err := os.ReadFile(filepath.Join(dir, "custom.yaml"))
+	if err != nil {
+		t.Fatalf("reading custom.yaml: %s", err)
+	}
+	if !strings.Contains(string(raw), "job: test") || !strings.Contains(string(raw), "paths: src/**") {
+		t.Errorf("expected custom job/paths, got:\n%s", raw)
+	}
+}
+
+func TestRunPromptsBeforeOverwrite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, defaultWorkflowName+".yaml")
+	if err := os.WriteFile(path, []byte("original"), 0644); err != nil {
+		t.Fatalf("seeding existing file: %s", err)
+	}
+
+	var out bytes.Buffer
+	if err := Run(dir, Options{}, strings.NewReader("n\n"), &out); err != nil {
+		t.Fatalf("Run: %s", err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading %s: %s", path, err)
+	}
+	if string(raw) != "original" {
+		t.Errorf("expected the file to be left untouched after declining, got:\n%s", raw)
+	}
+	if !strings.Contains(out.String(), "Overwrite?") {
+		t.Errorf("expected an overwrite prompt, got:\n%s", out.String())
+	}
+}
+
+func TestRunOverwritesOnConfirm(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, defaultWorkflowName+".yaml")
+	if err := os.WriteFile(path, []byte("original"), 0644); err != nil {
+		t.Fatalf("seeding existing file: %s", err)
+	}
+
+	var out bytes.Buffer
+	if err := Run(dir, Options{}, strings.NewReader("y\n"), &out); err != nil {
+		t.Fatalf("Run: %s", err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading %s: %s", path, err)
+	}
+	if string(raw) == "original" {
+		t.Errorf("expected the file to be overwritten after confirming")
+	}
+}
+
+func TestRunForceSkipsPrompt(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, defaultWorkflowName+".yaml")
+	if err := os.WriteFile(path, []byte("original"), 0644); err != nil {
+		t.Fatalf("seeding existing file: %s", err)
+	}
+
+	var out bytes.Buffer
+	opts := Options{Force: true}
+	if err := Run(dir, opts, strings.NewReader(""), &out); err != nil {
+		t.Fatalf("Run: %s", err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading %s: %s", path, err)
+	}
+	if string(raw) == "original" {
+		t.Errorf("expected force to overwrite without reading from in")
+	}
+	if strings.Contains(out.String(), "Overwrite?") {
+		t.Errorf("expected force to skip the prompt, got:\n%s", out.String())
+	}
+}
@@ -2,12 +2,20 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/go-github/v54/github"
+	"github.com/palsp/latest-successful-job-run/internal/changedpaths"
+	"github.com/palsp/latest-successful-job-run/internal/ghclient"
+	"github.com/palsp/latest-successful-job-run/internal/jobmatch"
+	"github.com/palsp/latest-successful-job-run/internal/resultcache"
+	"github.com/palsp/latest-successful-job-run/internal/scaffold"
 )
 
 const (
@@ -18,6 +26,79 @@ const (
 	githubRepository = "GITHUB_REPOSITORY"
 )
 
+// defaultMaxRuns bounds how many workflow runs we'll page through when no
+// "max-runs" input is supplied, so a misconfigured job name can't send us
+// scanning the repository's entire workflow history.
+const defaultMaxRuns = 200
+
+const runsPerPage = 100
+
+// runScanOptions controls which workflow runs are considered when looking
+// for the last run in which jobName was successful.
+type runScanOptions struct {
+	branch  string
+	event   string
+	status  string
+	actor   string
+	headSHA string
+	maxRuns int
+	maxAge  time.Duration
+}
+
+func getRunScanOptions() runScanOptions {
+	opts := runScanOptions{
+		branch:  getInput("branch", false),
+		event:   getInput("event", false),
+		status:  getInput("status", false),
+		actor:   getInput("actor", false),
+		headSHA: getInput("head-sha", false),
+		maxRuns: defaultMaxRuns,
+	}
+
+	if raw := getInput("max-runs", false); raw != "" {
+		maxRuns, err := strconv.Atoi(raw)
+		if err != nil {
+			log.Printf("Invalid max-runs %q, using default of %d: %s", raw, defaultMaxRuns, err)
+		} else {
+			opts.maxRuns = maxRuns
+		}
+	}
+
+	if raw := getInput("max-age", false); raw != "" {
+		maxAge, err := time.ParseDuration(raw)
+		if err != nil {
+			log.Printf("Invalid max-age %q, ignoring: %s", raw, err)
+		} else {
+			opts.maxAge = maxAge
+		}
+	}
+
+	return opts
+}
+
+func (o runScanOptions) listOptions(page int) *github.ListWorkflowRunsOptions {
+	return &github.ListWorkflowRunsOptions{
+		Branch:  o.branch,
+		Event:   o.event,
+		Status:  o.status,
+		Actor:   o.actor,
+		HeadSHA: o.headSHA,
+		ListOptions: github.ListOptions{
+			Page:    page,
+			PerPage: runsPerPage,
+		},
+	}
+}
+
+// withinAge reports whether run is recent enough to still be considered,
+// given a zero-value maxAge meaning "no bound".
+func (o runScanOptions) withinAge(run *github.WorkflowRun) bool {
+	if o.maxAge == 0 {
+		return true
+	}
+	return time.Since(run.GetCreatedAt().Time) <= o.maxAge
+}
+
 func getCurrentBranchName() string {
 	// if is pull request
 	if os.Getenv(githubEventName) == "pull_request" {
@@ -53,59 +134,361 @@ func setOutput(outputName string, value string) {
 	}
 }
 
-// Return the commit hash of the last workflow run in which the specified job was successful.
-// Defaults to the commit hash of the latest commit if the job was never successful or if this was the first run.
-func getLastSuccessfulWorkflowRunCommit(ctx context.Context, client *github.Client, jobName string) string {
+// jobResult is the outcome of resolving a single job spec: the commit at
+// which it last ran successfully, and when that run happened (used to pick
+// the combined, oldest SHA across specs).
+type jobResult struct {
+	sha             string
+	createdAt       time.Time
+	defaultedToHead bool
+}
+
+// Return, for each requested job spec, the commit hash of the last workflow run in which it was
+// successful. specs may be exact job names or glob patterns (e.g. "build (*)"); when
+// allMatrixLegsMustSucceed is set, a spec is only considered successful in a run once every
+// matrix leg sharing its base name has conclusion=success in that run.
+// Defaults to the commit hash of the latest commit for any spec never found successful.
+// opts narrows the runs considered (branch/event/status/actor/head SHA) and bounds how far back
+// the scan is allowed to page (maxRuns/maxAge), so the search doesn't run away on a busy repo.
+// caches, keyed by spec, lets previously resolved runs short-circuit the scan once pagination
+// reaches a run no newer than the one already cached.
+func getLastSuccessfulWorkflowRunCommits(ctx context.Context, client *github.Client, specs []string, allMatrixLegsMustSucceed bool, opts runScanOptions, caches map[string]*resultcache.Cache) map[string]jobResult {
 	owner_repo := strings.Split(os.Getenv(githubRepository), "/")
 	owner := owner_repo[0]
 	repo := owner_repo[1]
-	previousWorkflowRuns, _, err := client.Actions.ListRepositoryWorkflowRuns(ctx, owner, repo, nil)
-	if err != nil {
-		log.Printf("Error getting workflow runs: %s", err)
-		panic(err)
+
+	runExists := func(runID int64) bool {
+		_, _, err := client.Actions.GetWorkflowRunByID(ctx, owner, repo, runID)
+		return err == nil
 	}
 
-	// iterate the list of workflow from newest to oldest,
-	// if the workflow run contains the specified job and it was successful, return the commit hash
-	for _, workflowRun := range previousWorkflowRuns.WorkflowRuns {
-		if workflowRun.GetStatus() == "completed" {
+	results := make(map[string]jobResult, len(specs))
+	pending := make(map[string]bool, len(specs))
+	cacheBoundary := make(map[string]int64, len(specs))
+	cacheFallback := make(map[string]jobResult, len(specs))
+	for _, spec := range specs {
+		pending[spec] = true
+		if cache, ok := caches[spec]; ok {
+			if entry, ok := cache.Get(runExists); ok {
+				cacheBoundary[spec] = entry.RunID
+				cacheFallback[spec] = jobResult{sha: entry.HeadSHA, createdAt: entry.RunCreatedAt}
+			}
+		}
+	}
+
+	var firstRun *github.WorkflowRun
+	runsScanned := 0
+
+	for page := 1; len(pending) > 0; page++ {
+		previousWorkflowRuns, _, err := client.Actions.ListRepositoryWorkflowRuns(ctx, owner, repo, opts.listOptions(page))
+		if err != nil {
+			log.Printf("Error getting workflow runs: %s", err)
+			panic(err)
+		}
+
+		if len(previousWorkflowRuns.WorkflowRuns) == 0 {
+			break
+		}
+
+		stop := false
+
+		// iterate the list of workflow from newest to oldest,
+		// resolving each pending spec as soon as it's found successful
+		for _, workflowRun := range previousWorkflowRuns.WorkflowRuns {
+			if firstRun == nil {
+				firstRun = workflowRun
+			}
+
+			// a spec whose cached run is at or beyond this point has nothing newer to find;
+			// fall back to the cached entry instead of paging further for it
+			for spec, boundary := range cacheBoundary {
+				if pending[spec] && workflowRun.GetID() <= boundary {
+					results[spec] = cacheFallback[spec]
+					delete(pending, spec)
+				}
+			}
+			if len(pending) == 0 {
+				break
+			}
+
+			if !opts.withinAge(workflowRun) {
+				log.Printf("Run %d is older than max-age, stopping scan", workflowRun.GetID())
+				stop = true
+				break
+			}
+
+			runsScanned++
+			if runsScanned > opts.maxRuns {
+				log.Printf("Scanned %d runs without a match, stopping at max-runs=%d", runsScanned-1, opts.maxRuns)
+				stop = true
+				break
+			}
+
+			if workflowRun.GetStatus() != "completed" {
+				continue
+			}
+
 			workflowRunJobs, _, err := client.Actions.ListWorkflowJobs(ctx, owner, repo, workflowRun.GetID(), nil)
 			if err != nil {
 				log.Printf("Error getting workflow jobs: %s", err)
 				panic(err)
 			}
 
-			for _, workflowRunJob := range workflowRunJobs.Jobs {
-				log.Printf("Checking against job: %s", workflowRunJob.GetName())
-				if workflowRunJob.GetName() == jobName && workflowRunJob.GetStatus() == "completed" && workflowRunJob.GetConclusion() == "success" {
-					jobId := workflowRun.GetHeadCommit().GetID()
-					log.Printf("The hash of the latest commit in which the specified job was successful: %s", jobId)
-					return jobId
+			for spec := range pending {
+				if !specSucceededInRun(spec, workflowRunJobs.Jobs, allMatrixLegsMustSucceed) {
+					continue
+				}
+				results[spec] = jobResult{
+					sha:       workflowRun.GetHeadCommit().GetID(),
+					createdAt: workflowRun.GetCreatedAt().Time,
+				}
+				log.Printf("Job %q last succeeded at commit %s", spec, results[spec].sha)
+				delete(pending, spec)
+
+				if cache, ok := caches[spec]; ok {
+					cache.Set(resultcache.Entry{
+						RunID:        workflowRun.GetID(),
+						HeadSHA:      results[spec].sha,
+						RunCreatedAt: results[spec].createdAt,
+					})
 				}
 			}
+
+			if len(pending) == 0 {
+				break
+			}
+		}
+
+		if stop || page*runsPerPage >= opts.maxRuns {
+			break
 		}
 	}
 
-	// default to the commit hash of the latest commit
-	log.Printf("Unable to find the specified job in successful state in any of the previous workflow runs, defaulting to the latest commit hash")
-	return previousWorkflowRuns.WorkflowRuns[0].GetHeadCommit().GetID()
+	// default any unresolved spec to the commit hash of the latest commit
+	for spec := range pending {
+		log.Printf("Unable to find job %q in successful state in any of the previous workflow runs, defaulting to the latest commit hash", spec)
+		results[spec] = jobResult{sha: firstRun.GetHeadCommit().GetID(), defaultedToHead: true}
+	}
+
+	return results
+}
+
+// specSucceededInRun reports whether spec is considered successful within runJobs. In
+// all-matrix-legs-must-succeed mode every job sharing spec's base name must have completed
+// successfully; otherwise a single matching, successful job is enough.
+func specSucceededInRun(spec string, runJobs []*github.WorkflowJob, allMatrixLegsMustSucceed bool) bool {
+	if !allMatrixLegsMustSucceed {
+		for _, job := range runJobs {
+			if jobmatch.Matches(spec, job.GetName()) && job.GetStatus() == "completed" && job.GetConclusion() == "success" {
+				return true
+			}
+		}
+		return false
+	}
+
+	var legs []*github.WorkflowJob
+	for _, job := range runJobs {
+		if jobmatch.BaseName(job.GetName()) == jobmatch.BaseName(spec) {
+			legs = append(legs, job)
+		}
+	}
+	if len(legs) == 0 {
+		return false
+	}
+	for _, leg := range legs {
+		if leg.GetStatus() != "completed" || leg.GetConclusion() != "success" {
+			return false
+		}
+	}
+	return true
+}
+
+// getGHClientOptions reads the auth-related inputs (token, GitHub App
+// credentials, and GHES API URL) into a ghclient.Options.
+func getGHClientOptions() ghclient.Options {
+	appID, err := ghclient.ParseInt64(getInput("app-id", false))
+	if err != nil {
+		log.Printf("Invalid app-id, ignoring: %s", err)
+	}
+
+	installationID, err := ghclient.ParseInt64(getInput("installation-id", false))
+	if err != nil {
+		log.Printf("Invalid installation-id, ignoring: %s", err)
+	}
+
+	return ghclient.Options{
+		Token:          getInput("token", false),
+		AppID:          appID,
+		InstallationID: installationID,
+		PrivateKey:     getInput("private-key", false),
+		APIURL:         getInput("api-url", false),
+	}
+}
+
+// getResultCaches builds a resultcache.Cache per spec from the "cache" and "cache-ttl" inputs,
+// keyed by (repo, branch, spec) so different jobs and branches don't collide.
+func getResultCaches(specs []string, branch string) map[string]*resultcache.Cache {
+	enabled := getInput("cache", false) == "true"
+
+	var ttl time.Duration
+	if raw := getInput("cache-ttl", false); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil {
+			log.Printf("Invalid cache-ttl %q, ignoring: %s", raw, err)
+		} else {
+			ttl = parsed
+		}
+	}
+
+	repo := os.Getenv(githubRepository)
+	caches := make(map[string]*resultcache.Cache, len(specs))
+	for _, spec := range specs {
+		caches[spec] = resultcache.New(enabled, ttl, repo, branch, spec)
+	}
+	return caches
 }
 
 func main() {
+	args := os.Args[1:]
+
+	// Default to "run" so the action entrypoint (which never passes a subcommand) keeps working.
+	subcommand := "run"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		subcommand = args[0]
+		args = args[1:]
+	}
+
+	switch subcommand {
+	case "run":
+		runAction()
+	case "scaffold":
+		runScaffold(args)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown subcommand %q (expected \"run\" or \"scaffold\")\n", subcommand)
+		os.Exit(1)
+	}
+}
+
+// runAction is the action's normal entrypoint: resolve the last successful run per job, then
+// emit the SHA and changed-path outputs.
+func runAction() {
 	log.Printf("Starting the action")
 
-	ghClient := github.NewClient(nil)
 	ctx := context.Background()
 
+	ghClient, err := ghclient.New(ctx, getGHClientOptions())
+	if err != nil {
+		log.Printf("Error building GitHub client: %s", err)
+		panic(err)
+	}
+
 	input := getInput("paths", true)
-	job := getInput("job", true)
+	specs := jobmatch.ParseSpecs(getInput("job", true))
+	allMatrixLegsMustSucceed := getInput("all-matrix-legs-must-succeed", false) == "true"
+	scanOpts := getRunScanOptions()
+	caches := getResultCaches(specs, scanOpts.branch)
 
-	sha := getLastSuccessfulWorkflowRunCommit(ctx, ghClient, job)
+	results := getLastSuccessfulWorkflowRunCommits(ctx, ghClient, specs, allMatrixLegsMustSucceed, scanOpts, caches)
 
-	setOutput("sha", sha)
+	sha := emitJobOutputs(specs, results)
 
 	log.Printf("Paths: %s", input)
-	log.Printf("The commit hash of the last successful run of the specified job: %s", sha)
+	log.Printf("The combined (oldest) commit hash across all requested jobs: %s", sha)
 
 	log.Printf("Branch name is %s", getCurrentBranchName())
+
+	emitChangedPaths(ctx, ghClient, sha, parsePatterns(input))
+}
+
+// runScaffold implements the "scaffold" subcommand: parse its flags and write a starter workflow
+// under .github/workflows.
+func runScaffold(args []string) {
+	fs := flag.NewFlagSet("scaffold", flag.ExitOnError)
+	job := fs.String("job", "", "job name(s) to pass as the \"job\" input (default: build)")
+	paths := fs.String("paths", "", "glob pattern(s) to pass as the \"paths\" input (default: **)")
+	workflowName := fs.String("workflow-name", "", "name of the generated workflow file, without extension")
+	force := fs.Bool("force", false, "overwrite an existing workflow file without prompting")
+	fs.Parse(args)
+
+	opts := scaffold.Options{
+		Job:          *job,
+		Paths:        *paths,
+		WorkflowName: *workflowName,
+		Force:        *force,
+	}
+
+	if err := scaffold.Run(".github/workflows", opts, os.Stdin, os.Stdout); err != nil {
+		log.Printf("Error scaffolding workflow: %s", err)
+		panic(err)
+	}
+}
+
+// emitJobOutputs sets "sha_<job>" for each requested spec and the combined "sha" output (the
+// oldest commit among them, so diffing against it covers every job). It returns that combined
+// SHA for callers that need it directly.
+func emitJobOutputs(specs []string, results map[string]jobResult) string {
+	var oldest string
+	var oldestAt time.Time
+
+	for _, spec := range specs {
+		result := results[spec]
+		setOutput(fmt.Sprintf("sha_%s", outputKey(spec)), result.sha)
+
+		if oldest == "" || (!result.defaultedToHead && (oldestAt.IsZero() || result.createdAt.Before(oldestAt))) {
+			oldest = result.sha
+			oldestAt = result.createdAt
+		}
+	}
+
+	setOutput("sha", oldest)
+	return oldest
+}
+
+// outputKey sanitizes a job spec for use as an output name suffix, since
+// GitHub Actions output names can't contain spaces, parentheses, or commas.
+func outputKey(spec string) string {
+	replacer := strings.NewReplacer(" ", "_", "(", "", ")", "", ",", "_", "*", "x")
+	return replacer.Replace(spec)
+}
+
+// parsePatterns splits the "paths" input on newlines and commas, trimming
+// whitespace, so users can supply either a YAML block scalar or a
+// comma-separated list.
+func parsePatterns(input string) []string {
+	fields := strings.FieldsFunc(input, func(r rune) bool {
+		return r == '\n' || r == ','
+	})
+
+	var patterns []string
+	for _, f := range fields {
+		if trimmed := strings.TrimSpace(f); trimmed != "" {
+			patterns = append(patterns, trimmed)
+		}
+	}
+	return patterns
+}
+
+// emitChangedPaths sets the "changed", "changed-files" and "any-matched"
+// outputs describing what changed between sha and the current head commit,
+// filtered against patterns.
+func emitChangedPaths(ctx context.Context, client *github.Client, sha string, patterns []string) {
+	owner_repo := strings.Split(os.Getenv(githubRepository), "/")
+	owner := owner_repo[0]
+	repo := owner_repo[1]
+
+	headSHA, err := changedpaths.CurrentHeadSHA()
+	if err != nil {
+		log.Printf("Error resolving the current head SHA: %s", err)
+		panic(err)
+	}
+
+	result, err := changedpaths.Resolve(ctx, client, owner, repo, sha, headSHA, patterns)
+	if err != nil {
+		log.Printf("Error resolving changed paths: %s", err)
+		panic(err)
+	}
+
+	setOutput("changed", strings.Join(result.Matched, "\n"))
+	setOutput("changed-files", strings.Join(result.Files, "\n"))
+	setOutput("any-matched", strconv.FormatBool(result.AnyMatched()))
 }